@@ -0,0 +1,87 @@
+package pmac
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"encoding"
+	"testing"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	key := make([]byte, 16)
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("the quick brown fox jumps over the lazy dog, and then some more")
+
+	want := New(c)
+	want.Write(msg)
+	wantTag := want.Sum(nil)
+
+	h := New(c)
+	h.Write(msg[:20])
+
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resumed := New(c)
+	if err := resumed.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	resumed.Write(msg[20:])
+
+	if got := resumed.Sum(nil); !bytes.Equal(got, wantTag) {
+		t.Errorf("resumed tag = %X, want %X", got, wantTag)
+	}
+}
+
+func TestUnmarshalBlockSizeMismatch(t *testing.T) {
+	aesKey := make([]byte, 16)
+	aesCipher, err := aes.NewCipher(aesKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(aesCipher)
+	h.Write([]byte("hello"))
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desKey := make([]byte, 8)
+	desCipher, err := des.NewCipher(desKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := New(desCipher)
+	if err := other.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != errInvalidHashStateSize {
+		t.Errorf("UnmarshalBinary across block sizes error = %v, want %v", err, errInvalidHashStateSize)
+	}
+}
+
+func TestUnmarshalTagSizeMismatch(t *testing.T) {
+	key := make([]byte, 16)
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewWithTagSize(c, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write([]byte("hello"))
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full := New(c)
+	if err := full.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != errTagSizeMismatch {
+		t.Errorf("UnmarshalBinary across tag sizes error = %v, want %v", err, errTagSizeMismatch)
+	}
+}
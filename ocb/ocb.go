@@ -0,0 +1,315 @@
+// Package ocb implements the OCB authenticated encryption mode defined in
+// https://web.cs.ucdavis.edu/~rogaway/ocb/ocb3.pdf and RFC 7253. OCB shares
+// its L-table doubling scheme and Gray-code offset sequence with PMAC,
+// defined in the parent package, but authenticates associated data with
+// RFC 7253's own HASH function rather than the parent package's PMAC: HASH
+// starts its offset sequence at L_0 (not L_*) and has no final lInv-XOR or
+// extra encryption of the accumulated sum, so it is not the same function.
+package ocb
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"math/bits"
+	"sync"
+)
+
+const blockSize = 16
+
+var (
+	errInvalidBlockSize = errors.New("ocb: cipher block size must be 128 bits")
+	errInvalidNonceSize = errors.New("ocb: invalid nonce size")
+	errInvalidTagSize   = errors.New("ocb: invalid tag size")
+	errOpen             = errors.New("ocb: message authentication failed")
+)
+
+type ocb struct {
+	c         cipher.Block
+	nonceSize int
+	tagSize   int
+	lAst      []byte // L_*
+	lDol      []byte // L_$
+
+	lMu sync.Mutex
+	l   [][]byte
+}
+
+// NewOCB returns an OCB AEAD using c for its underlying block cipher, with
+// the given nonce and tag sizes. c's block size must be 128 bits (e.g. AES,
+// Twofish), since the nonce processing below is defined in terms of it.
+func NewOCB(c cipher.Block, nonceSize, tagSize int) (cipher.AEAD, error) {
+	if c.BlockSize() != blockSize {
+		return nil, errInvalidBlockSize
+	}
+	if nonceSize < 1 || nonceSize >= blockSize {
+		return nil, errInvalidNonceSize
+	}
+	if tagSize < 1 || tagSize > blockSize {
+		return nil, errInvalidTagSize
+	}
+
+	o := &ocb{c: c, nonceSize: nonceSize, tagSize: tagSize}
+
+	zero := make([]byte, blockSize)
+	lAst := make([]byte, blockSize)
+	c.Encrypt(lAst, zero)
+	o.lAst = lAst
+
+	lDol := make([]byte, blockSize)
+	copy(lDol, lAst)
+	double(lDol)
+	o.lDol = lDol
+
+	l0 := make([]byte, blockSize)
+	copy(l0, lDol)
+	double(l0)
+	o.l = [][]byte{l0}
+
+	return o, nil
+}
+
+func (o *ocb) NonceSize() int { return o.nonceSize }
+
+func (o *ocb) Overhead() int { return o.tagSize }
+
+// getL returns L_i from the precomputed table, doubling further entries
+// into it on demand. Seal and Open must be safe for concurrent use like
+// other cipher.AEAD implementations, so table growth is guarded by a mutex.
+func (o *ocb) getL(i int) []byte {
+	o.lMu.Lock()
+	defer o.lMu.Unlock()
+
+	for len(o.l) <= i {
+		next := make([]byte, blockSize)
+		copy(next, o.l[len(o.l)-1])
+		double(next)
+		o.l = append(o.l, next)
+	}
+	return o.l[i]
+}
+
+func (o *ocb) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != o.nonceSize {
+		panic("ocb: invalid nonce length")
+	}
+
+	offset := o.initialOffset(nonce)
+	checksum := make([]byte, blockSize)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+o.tagSize)
+	ciphertext := out[:len(plaintext)]
+
+	full := len(plaintext) / blockSize
+	for i := 0; i < full; i++ {
+		block := plaintext[i*blockSize : (i+1)*blockSize]
+		xor(offset, o.getL(bits.TrailingZeros(uint(i+1))))
+
+		tmp := make([]byte, blockSize)
+		xor2(tmp, block, offset)
+		o.c.Encrypt(tmp, tmp)
+		xor2(ciphertext[i*blockSize:(i+1)*blockSize], tmp, offset)
+
+		xor(checksum, block)
+	}
+
+	if rem := plaintext[full*blockSize:]; len(rem) > 0 {
+		xor(offset, o.lAst)
+
+		pad := make([]byte, blockSize)
+		o.c.Encrypt(pad, offset)
+		xor2(ciphertext[full*blockSize:], rem, pad[:len(rem)])
+
+		padded := make([]byte, blockSize)
+		copy(padded, rem)
+		padded[len(rem)] ^= 0x80
+		xor(checksum, padded)
+	}
+
+	xor(offset, o.lDol)
+	tag := make([]byte, blockSize)
+	xor2(tag, checksum, offset)
+	o.c.Encrypt(tag, tag)
+
+	if len(additionalData) > 0 {
+		xor(tag, o.hashAD(additionalData))
+	}
+
+	copy(out[len(plaintext):], tag[:o.tagSize])
+
+	return ret
+}
+
+func (o *ocb) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != o.nonceSize {
+		panic("ocb: invalid nonce length")
+	}
+	if len(ciphertext) < o.tagSize {
+		return nil, errOpen
+	}
+
+	ct := ciphertext[:len(ciphertext)-o.tagSize]
+	wantTag := ciphertext[len(ciphertext)-o.tagSize:]
+
+	offset := o.initialOffset(nonce)
+	checksum := make([]byte, blockSize)
+
+	ret, out := sliceForAppend(dst, len(ct))
+	plaintext := out
+
+	full := len(ct) / blockSize
+	for i := 0; i < full; i++ {
+		block := ct[i*blockSize : (i+1)*blockSize]
+		xor(offset, o.getL(bits.TrailingZeros(uint(i+1))))
+
+		tmp := make([]byte, blockSize)
+		xor2(tmp, block, offset)
+		o.c.Decrypt(tmp, tmp)
+		xor2(plaintext[i*blockSize:(i+1)*blockSize], tmp, offset)
+
+		xor(checksum, plaintext[i*blockSize:(i+1)*blockSize])
+	}
+
+	if rem := ct[full*blockSize:]; len(rem) > 0 {
+		xor(offset, o.lAst)
+
+		pad := make([]byte, blockSize)
+		o.c.Encrypt(pad, offset)
+		xor2(plaintext[full*blockSize:], rem, pad[:len(rem)])
+
+		padded := make([]byte, blockSize)
+		copy(padded, plaintext[full*blockSize:])
+		padded[len(rem)] ^= 0x80
+		xor(checksum, padded)
+	}
+
+	xor(offset, o.lDol)
+	tag := make([]byte, blockSize)
+	xor2(tag, checksum, offset)
+	o.c.Encrypt(tag, tag)
+
+	if len(additionalData) > 0 {
+		xor(tag, o.hashAD(additionalData))
+	}
+
+	if subtle.ConstantTimeCompare(tag[:o.tagSize], wantTag) != 1 {
+		clear(out)
+		return nil, errOpen
+	}
+
+	return ret, nil
+}
+
+// hashAD computes RFC 7253's HASH(K, A) function over additionalData,
+// reusing the same L-table as the message processing above but with its
+// own offset sequence, which starts at zero rather than at a nonce-derived
+// Offset_0.
+func (o *ocb) hashAD(additionalData []byte) []byte {
+	sum := make([]byte, blockSize)
+	offset := make([]byte, blockSize)
+
+	full := len(additionalData) / blockSize
+	for i := 0; i < full; i++ {
+		block := additionalData[i*blockSize : (i+1)*blockSize]
+		xor(offset, o.getL(bits.TrailingZeros(uint(i+1))))
+
+		tmp := make([]byte, blockSize)
+		xor2(tmp, block, offset)
+		o.c.Encrypt(tmp, tmp)
+		xor(sum, tmp)
+	}
+
+	if rem := additionalData[full*blockSize:]; len(rem) > 0 {
+		xor(offset, o.lAst)
+
+		in := make([]byte, blockSize)
+		copy(in, rem)
+		in[len(rem)] ^= 0x80
+		xor(in, offset)
+
+		o.c.Encrypt(in, in)
+		xor(sum, in)
+	}
+
+	return sum
+}
+
+// initialOffset computes Offset_0 for nonce, following the "bottom" and
+// "stretch" construction from RFC 7253 section 4.
+func (o *ocb) initialOffset(nonce []byte) []byte {
+	var n [blockSize]byte
+	n[blockSize-1-o.nonceSize] = 1
+	copy(n[blockSize-o.nonceSize:], nonce)
+	n[0] |= byte((o.tagSize * 8 % 128) << 1)
+
+	bottom := int(n[blockSize-1] & 0x3f)
+	n[blockSize-1] &= 0xc0
+
+	ktop := make([]byte, blockSize)
+	o.c.Encrypt(ktop, n[:])
+
+	stretch := make([]byte, blockSize+8)
+	copy(stretch, ktop)
+	for i := 0; i < 8; i++ {
+		stretch[blockSize+i] = ktop[i] ^ ktop[i+1]
+	}
+
+	offset := make([]byte, blockSize)
+	byteShift := bottom / 8
+	bitShift := uint(bottom % 8)
+	for i := 0; i < blockSize; i++ {
+		b := stretch[byteShift+i] << bitShift
+		if bitShift > 0 {
+			b |= stretch[byteShift+i+1] >> (8 - bitShift)
+		}
+		offset[i] = b
+	}
+
+	return offset
+}
+
+// double doubles b in place in GF(2^128), using the same r128 reduction
+// polynomial PMAC uses for 16-byte blocks.
+func double(b []byte) {
+	const r128 = 0x87
+
+	var z byte
+	for i := len(b) - 1; i >= 0; i-- {
+		zz := b[i] >> 7
+		b[i] = b[i]<<1 | z
+		z = zz
+	}
+	b[len(b)-1] ^= byte(subtle.ConstantTimeSelect(int(z), r128, 0))
+}
+
+func xor(a, b []byte) {
+	for i, v := range b {
+		a[i] ^= v
+	}
+}
+
+func xor2(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
+
+func clear(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// sliceForAppend takes a slice and a requested number of bytes, returning a
+// slice with the extra capacity appended (and the original data present),
+// along with a second slice pointing to the newly appended bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
@@ -0,0 +1,151 @@
+package ocb
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+)
+
+// RFC 7253 Appendix A test vectors for AES-128, 128-bit tag. Nonces follow
+// the pattern BBAA99887766554433221100 + i.
+var rfc7253Vectors = []struct {
+	nonce, a, p, c string
+}{
+	{
+		nonce: "BBAA99887766554433221100",
+		a:     "",
+		p:     "",
+		c:     "785407BFFFC8AD9EDCC5520AC9111EE6",
+	},
+	{
+		nonce: "BBAA99887766554433221102",
+		a:     "0001020304050607",
+		p:     "",
+		c:     "81017F8203F081277152FADE694A0A00",
+	},
+	{
+		// 8-byte P: a single partial block.
+		nonce: "BBAA99887766554433221101",
+		a:     "0001020304050607",
+		p:     "0001020304050607",
+		c:     "6820B3657B6F615A5725BDA0D3B4EB3A257C9AF1F8F03009",
+	},
+	{
+		// 16-byte P: exactly one full block.
+		nonce: "BBAA99887766554433221104",
+		a:     "000102030405060708090A0B0C0D0E0F",
+		p:     "000102030405060708090A0B0C0D0E0F",
+		c:     "571D535B60B277188BE5147170A9A22C3AD7A4FF3835B8C5701C1CCEC8FC3358",
+	},
+	{
+		// 24-byte P: one full block plus a partial block.
+		nonce: "BBAA99887766554433221107",
+		a:     "000102030405060708090A0B0C0D0E0F1011121314151617",
+		p:     "000102030405060708090A0B0C0D0E0F1011121314151617",
+		c:     "1CA2207308C87C010756104D8840CE1952F09673A448A122C92C62241051F57356D7F3C90BB0E07F",
+	},
+}
+
+func TestRFC7253Vectors(t *testing.T) {
+	key, err := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range rfc7253Vectors {
+		nonce, err := hex.DecodeString(v.nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := hex.DecodeString(v.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := hex.DecodeString(v.p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := hex.DecodeString(v.c)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		aead, err := NewOCB(c, len(nonce), 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := aead.Seal(nil, nonce, p, a)
+		if !bytes.Equal(got, want) {
+			t.Errorf("Seal(nonce=%s, A=%s, P=%s) = %X, want %X", v.nonce, v.a, v.p, got, want)
+		}
+	}
+}
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	key, err := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aead, err := NewOCB(c, 12, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nonce := make([]byte, 12)
+	msg := make([]byte, 64)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	ad := make([]byte, 64)
+	for i := range ad {
+		ad[i] = byte(i ^ 0xff)
+	}
+
+	lengths := []int{0, 1, 15, 16, 17, 31, 32, 33}
+	for _, pLen := range lengths {
+		for _, aLen := range lengths {
+			p := msg[:pLen]
+			a := ad[:aLen]
+
+			ct := aead.Seal(nil, nonce, p, a)
+			if len(ct) != pLen+aead.Overhead() {
+				t.Fatalf("len(P)=%d len(A)=%d: ciphertext length = %d, want %d", pLen, aLen, len(ct), pLen+aead.Overhead())
+			}
+
+			pt, err := aead.Open(nil, nonce, ct, a)
+			if err != nil {
+				t.Fatalf("len(P)=%d len(A)=%d: Open failed: %v", pLen, aLen, err)
+			}
+			if !bytes.Equal(pt, p) {
+				t.Fatalf("len(P)=%d len(A)=%d: Open = %X, want %X", pLen, aLen, pt, p)
+			}
+
+			if len(ct) > 0 {
+				tampered := append([]byte(nil), ct...)
+				tampered[0] ^= 1
+				if _, err := aead.Open(nil, nonce, tampered, a); err == nil {
+					t.Fatalf("len(P)=%d len(A)=%d: Open succeeded on tampered ciphertext", pLen, aLen)
+				}
+			}
+
+			if aLen > 0 {
+				tamperedA := append([]byte(nil), a...)
+				tamperedA[0] ^= 1
+				if _, err := aead.Open(nil, nonce, ct, tamperedA); err == nil {
+					t.Fatalf("len(P)=%d len(A)=%d: Open succeeded on tampered AD", pLen, aLen)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package pmac
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+)
+
+// Known-answer tests pinning the r64 doubling polynomial (0x1b) used for
+// 8-byte block ciphers, distinguishing it from the r128 polynomial (0x87)
+// used for 16-byte blocks.
+func TestR64KnownAnswer(t *testing.T) {
+	msg := []byte("The quick brown fox jumps over the lazy dog")
+
+	key, err := hex.DecodeString("0123456789ABCDEF")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := des.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := New(c)
+	h.Write(msg)
+	got := h.Sum(nil)
+	want, _ := hex.DecodeString("1DC7287B6FE6A5BC")
+	if !bytes.Equal(got, want) {
+		t.Errorf("DES PMAC tag = %X, want %X", got, want)
+	}
+
+	key3, err := hex.DecodeString("0123456789ABCDEF23456789ABCDEF01456789ABCDEF0123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c3, err := des.NewTripleDESCipher(key3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h3 := New(c3)
+	h3.Write(msg)
+	got3 := h3.Sum(nil)
+	want3, _ := hex.DecodeString("CEA50EAAFD4537F8")
+	if !bytes.Equal(got3, want3) {
+		t.Errorf("3DES PMAC tag = %X, want %X", got3, want3)
+	}
+}
+
+func TestNewWithTagSize(t *testing.T) {
+	key := make([]byte, 16)
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewWithTagSize(c, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Size() != 8 {
+		t.Errorf("Size() = %d, want 8", h.Size())
+	}
+
+	h.Write([]byte("message"))
+	if tag := h.Sum(nil); len(tag) != 8 {
+		t.Errorf("len(Sum(nil)) = %d, want 8", len(tag))
+	}
+
+	for _, tagsize := range []int{0, -1, 17} {
+		if _, err := NewWithTagSize(c, tagsize); err != errInvalidTagSize {
+			t.Errorf("NewWithTagSize(c, %d) error = %v, want %v", tagsize, err, errInvalidTagSize)
+		}
+	}
+}
+
+func TestSumVerify(t *testing.T) {
+	key := make([]byte, 16)
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("message")
+
+	tag, err := Sum(msg, c, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tag) != 8 {
+		t.Errorf("len(Sum) = %d, want 8", len(tag))
+	}
+
+	if !Verify(tag, msg, c, 8) {
+		t.Error("Verify of a genuine tag = false, want true")
+	}
+
+	tampered := append([]byte(nil), tag...)
+	tampered[0] ^= 1
+	if Verify(tampered, msg, c, 8) {
+		t.Error("Verify of a tampered tag = true, want false")
+	}
+
+	if Verify(tag, []byte("other message"), c, 8) {
+		t.Error("Verify against a different message = true, want false")
+	}
+}